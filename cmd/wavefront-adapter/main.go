@@ -18,9 +18,11 @@ package main
 
 import (
 	"flag"
+	"fmt"
 	"net/url"
 	"os"
 	"runtime"
+	"strings"
 	"time"
 
 	log "github.com/sirupsen/logrus"
@@ -52,12 +54,54 @@ type WavefrontAdapter struct {
 	WavefrontServerURL string
 	// Wavefront API token with permissions to query points
 	WavefrontAPIToken string
+	// Path to a file containing the Wavefront API token; takes precedence
+	// over WavefrontAPIToken and is re-read whenever it changes on disk
+	WavefrontTokenFile string
+	// namespace/name/key of a Kubernetes Secret containing the Wavefront
+	// API token; takes precedence over WavefrontTokenFile and WavefrontAPIToken
+	WavefrontTokenSecret string
 	// The prefix for custom kubernetes metrics in Wavefront
 	CustomMetricPrefix string
 	// The file containing the metrics discovery configuration
 	AdapterConfigFile string
 	// The log level
 	LogLevel string
+	// MaxRetries is how many additional attempts are made for a failed,
+	// idempotent (GET) request against Wavefront before giving up
+	MaxRetries int
+	// RetryBaseDelay is the base delay used for exponential backoff
+	// between retries against Wavefront
+	RetryBaseDelay time.Duration
+	// RequestTimeout bounds a single HTTP round trip to Wavefront
+	RequestTimeout time.Duration
+	// BreakerThreshold is the number of consecutive failures against a
+	// Wavefront endpoint before its circuit breaker trips open
+	BreakerThreshold int
+	// MetricsBackend selects which metrics store the adapter queries:
+	// "wavefront" (default) or "prometheus"
+	MetricsBackend string
+	// PrometheusURL is the base URL of the Prometheus-compatible API used
+	// when MetricsBackend is "prometheus"
+	PrometheusURL string
+	// PrometheusBearerToken authenticates against PrometheusURL when
+	// MetricsBackend is "prometheus"
+	PrometheusBearerToken string
+	// ExternalMetricsQueryDialect is the query language ExternalCfg's
+	// queries are authored in: "wavefront" (default) or "promql". It only
+	// matters when MetricsBackend is "prometheus", where it decides
+	// whether queries need translating from WQL before they're sent.
+	ExternalMetricsQueryDialect string
+	// DryRun swaps the metrics backend client for a recorder that logs
+	// each would-be call and returns synthetic results instead of
+	// contacting a live backend
+	DryRun bool
+	// DryRunFixtures is the path to a YAML file of synthetic ListMetrics/
+	// Query results served when DryRun is set
+	DryRunFixtures string
+	// MetricDiscoveryHotInterval is how often the incremental metric
+	// discoverer relists prefixes referenced by an active HPA, in
+	// addition to the slower MetricsRelistInterval-paced full relist
+	MetricDiscoveryHotInterval time.Duration
 }
 
 func (a *WavefrontAdapter) makeProviderOrDie() customprovider.MetricsProvider {
@@ -80,11 +124,63 @@ func (a *WavefrontAdapter) makeProviderOrDie() customprovider.MetricsProvider {
 		log.Fatalf("unable to construct discovery REST mapper: %v", err)
 	}
 
-	waveURL, err := url.Parse(a.WavefrontServerURL)
+	backendURL, tokenSource, err := a.backendEndpoint(kubeClient)
 	if err != nil {
-		log.Fatalf("unable to parse wavefront url: %v", err)
+		log.Fatalf("unable to configure %s metrics backend: %v", a.MetricsBackend, err)
 	}
-	waveClient := client.NewWavefrontClient(waveURL, a.WavefrontAPIToken)
+	queryDialect := client.QueryDialect(a.ExternalMetricsQueryDialect)
+	if queryDialect != client.DialectWavefront && queryDialect != client.DialectPromQL {
+		log.Fatalf("invalid --external-metrics-query-dialect %q, expected %q or %q",
+			a.ExternalMetricsQueryDialect, client.DialectWavefront, client.DialectPromQL)
+	}
+	waveClient, err := client.NewClient(a.MetricsBackend, client.BackendConfig{
+		BaseURL:     backendURL,
+		TokenSource: tokenSource,
+		ClientConfig: client.ClientConfig{
+			MaxRetries:       a.MaxRetries,
+			RetryBaseDelay:   a.RetryBaseDelay,
+			RequestTimeout:   a.RequestTimeout,
+			BreakerThreshold: a.BreakerThreshold,
+			QueryDialect:     queryDialect,
+		},
+	})
+	if err != nil {
+		log.Fatalf("unable to construct %s metrics client: %v", a.MetricsBackend, err)
+	}
+
+	if a.DryRun {
+		fixtures, err := client.LoadFixtures(a.DryRunFixtures)
+		if err != nil {
+			log.Fatalf("unable to load dry-run fixtures: %v", err)
+		}
+		log.Infof("dry-run enabled: logging would-be %s calls instead of contacting a live backend", a.MetricsBackend)
+		waveClient = client.NewRecordingClient(backendURL, fixtures)
+	}
+
+	// Seed and run the incremental metric discoverer for the configured
+	// prefix alongside the provider's own full relist: it issues narrow,
+	// prefix-scoped ListMetrics calls on a hot interval while an HPA is
+	// actively scaling on the prefix (and a slower cold interval
+	// otherwise), and reports only the Added/Deleted deltas. registry
+	// applies those deltas to a real, incrementally-updated registration
+	// map (see MetricRegistry's doc comment for why that map can't be
+	// WavefrontProvider's own: WavefrontProvider isn't defined anywhere in
+	// this tree). hotSet keeps the prefix's hot/cold state in sync with
+	// whether any HorizontalPodAutoscaler currently references it, instead
+	// of pinning it hot forever.
+	registry := provider.NewMetricRegistry()
+	discoverer := provider.NewMetricDiscoverer(waveClient, provider.DiscoverySinkFunc(func(deltas []provider.DiscoveryDelta) {
+		registry.OnMetricDeltas(deltas)
+		logMetricDeltas(deltas)
+	}), provider.DiscovererConfig{
+		HotInterval:  a.MetricDiscoveryHotInterval,
+		ColdInterval: a.MetricsRelistInterval,
+	})
+	discoverer.Relist(a.CustomMetricPrefix)
+	go discoverer.Run(wait.NeverStop)
+
+	hotSet := provider.NewHPAHotSetRunner(kubeClient, discoverer, a.CustomMetricPrefix, provider.DefaultHPAHotSetConfig())
+	go hotSet.Run(wait.NeverStop)
 
 	metricsProvider, runnable := provider.NewWavefrontProvider(provider.WavefrontProviderConfig{
 		DynClient:    dynClient,
@@ -99,6 +195,60 @@ func (a *WavefrontAdapter) makeProviderOrDie() customprovider.MetricsProvider {
 	return metricsProvider
 }
 
+// logMetricDeltas reports the metric names a MetricDiscoverer relist found
+// added or deleted for a prefix.
+func logMetricDeltas(deltas []provider.DiscoveryDelta) {
+	for _, d := range deltas {
+		switch d.Type {
+		case provider.Added:
+			log.Infof("metric discovery: %s: +%s", d.Prefix, d.Metric)
+		case provider.Deleted:
+			log.Infof("metric discovery: %s: -%s", d.Prefix, d.Metric)
+		}
+	}
+}
+
+// tokenSource builds the client.TokenSource used to authenticate against
+// Wavefront, preferring a Secret reference over a token file over the
+// static --wavefront-token value, in that order.
+func (a *WavefrontAdapter) tokenSource(kubeClient kubernetes.Interface) (client.TokenSource, error) {
+	switch {
+	case a.WavefrontTokenSecret != "":
+		parts := strings.SplitN(a.WavefrontTokenSecret, "/", 3)
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("invalid --wavefront-token-secret %q, expected namespace/name/key", a.WavefrontTokenSecret)
+		}
+		return client.NewSecretTokenSource(kubeClient, parts[0], parts[1], parts[2]), nil
+	case a.WavefrontTokenFile != "":
+		return client.NewFileTokenSource(a.WavefrontTokenFile)
+	default:
+		return client.NewStaticTokenSource(a.WavefrontAPIToken), nil
+	}
+}
+
+// backendEndpoint resolves the base URL and TokenSource for the configured
+// --metrics-backend.
+func (a *WavefrontAdapter) backendEndpoint(kubeClient kubernetes.Interface) (*url.URL, client.TokenSource, error) {
+	switch a.MetricsBackend {
+	case "prometheus":
+		backendURL, err := url.Parse(a.PrometheusURL)
+		if err != nil {
+			return nil, nil, fmt.Errorf("unable to parse prometheus url: %v", err)
+		}
+		return backendURL, client.NewStaticTokenSource(a.PrometheusBearerToken), nil
+	default:
+		backendURL, err := url.Parse(a.WavefrontServerURL)
+		if err != nil {
+			return nil, nil, fmt.Errorf("unable to parse wavefront url: %v", err)
+		}
+		tokenSource, err := a.tokenSource(kubeClient)
+		if err != nil {
+			return nil, nil, fmt.Errorf("unable to build wavefront token source: %v", err)
+		}
+		return backendURL, tokenSource, nil
+	}
+}
+
 func main() {
 	log.SetFormatter(&log.TextFormatter{})
 	log.SetLevel(log.InfoLevel)
@@ -111,9 +261,17 @@ func main() {
 		runtime.GOMAXPROCS(runtime.NumCPU())
 	}
 
+	defaultClientCfg := client.DefaultClientConfig()
 	cmd := &WavefrontAdapter{
-		CustomMetricPrefix:    "kubernetes",
-		MetricsRelistInterval: 10 * time.Minute,
+		CustomMetricPrefix:          "kubernetes",
+		MetricsRelistInterval:       10 * time.Minute,
+		MaxRetries:                  defaultClientCfg.MaxRetries,
+		RetryBaseDelay:              defaultClientCfg.RetryBaseDelay,
+		RequestTimeout:              defaultClientCfg.RequestTimeout,
+		BreakerThreshold:            defaultClientCfg.BreakerThreshold,
+		MetricsBackend:              "wavefront",
+		ExternalMetricsQueryDialect: string(defaultClientCfg.QueryDialect),
+		MetricDiscoveryHotInterval:  provider.DefaultDiscovererConfig().HotInterval,
 	}
 	cmd.Name = "wavefront-custom-metrics-adapter"
 	flags := cmd.Flags()
@@ -123,10 +281,39 @@ func main() {
 		"Wavefront url of the form https://INSTANCE.wavefront.com")
 	flags.StringVar(&cmd.WavefrontAPIToken, "wavefront-token", "",
 		"Wavefront API token with permissions to query for points")
+	flags.StringVar(&cmd.WavefrontTokenFile, "wavefront-token-file", "",
+		"path to a file containing the Wavefront API token; the file is re-read when it "+
+			"changes so the token can be rotated without restarting the adapter pod")
+	flags.StringVar(&cmd.WavefrontTokenSecret, "wavefront-token-secret", "",
+		"namespace/name/key of a Kubernetes Secret containing the Wavefront API token, "+
+			"resolved through the adapter's in-cluster client")
 	flags.StringVar(&cmd.CustomMetricPrefix, "wavefront-metric-prefix", cmd.CustomMetricPrefix,
 		"Wavefront Kubernetes Metrics Prefix")
 	flags.StringVar(&cmd.AdapterConfigFile, "external-metrics-config", "",
 		"Configuration file for driving external metrics API")
+	flags.IntVar(&cmd.MaxRetries, "wavefront-max-retries", cmd.MaxRetries,
+		"number of retries for a failed, idempotent Wavefront request before giving up")
+	flags.DurationVar(&cmd.RetryBaseDelay, "wavefront-retry-base-delay", cmd.RetryBaseDelay,
+		"base delay for exponential backoff between Wavefront request retries")
+	flags.DurationVar(&cmd.RequestTimeout, "wavefront-request-timeout", cmd.RequestTimeout,
+		"timeout for a single HTTP round trip to Wavefront")
+	flags.IntVar(&cmd.BreakerThreshold, "wavefront-breaker-threshold", cmd.BreakerThreshold,
+		"number of consecutive failures against a Wavefront endpoint before its circuit breaker trips open")
+	flags.StringVar(&cmd.MetricsBackend, "metrics-backend", cmd.MetricsBackend,
+		"metrics store to query: \"wavefront\" or \"prometheus\"")
+	flags.StringVar(&cmd.PrometheusURL, "prometheus-url", "",
+		"base URL of the Prometheus-compatible API, used when --metrics-backend=prometheus")
+	flags.StringVar(&cmd.PrometheusBearerToken, "prometheus-bearer-token", "",
+		"bearer token for the Prometheus-compatible API, used when --metrics-backend=prometheus")
+	flags.StringVar(&cmd.ExternalMetricsQueryDialect, "external-metrics-query-dialect", cmd.ExternalMetricsQueryDialect,
+		"query language --external-metrics-config's queries are authored in: \"wavefront\" or \"promql\". "+
+			"Only matters when --metrics-backend=prometheus, where \"wavefront\" queries are translated before being sent.")
+	flags.BoolVar(&cmd.DryRun, "dry-run", false,
+		"log would-be Query/ListMetrics calls and return synthetic results instead of contacting a live metrics backend")
+	flags.StringVar(&cmd.DryRunFixtures, "dry-run-fixtures", "",
+		"YAML file of synthetic ListMetrics/Query results served when --dry-run is set")
+	flags.DurationVar(&cmd.MetricDiscoveryHotInterval, "metric-discovery-hot-interval", cmd.MetricDiscoveryHotInterval,
+		"interval at which metrics referenced by an active HPA are relisted, independent of --metrics-relist-interval")
 	flags.StringVar(&cmd.LogLevel, "log-level", "info", "one of info, debug or trace")
 	flags.StringVar(&cmd.Message, "msg", "starting wavefront adapter", "startup message")
 	flags.AddGoFlagSet(flag.CommandLine) // make sure we get the glog flags
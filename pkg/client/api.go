@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"math/rand"
 	"net/http"
 	"net/url"
 	"path"
@@ -19,22 +20,59 @@ type WavefrontClient interface {
 	Query(ts int64, query string) (QueryResult, error)
 }
 
+// ClientConfig holds the tunables for DefaultWavefrontClient's timeout,
+// retry and circuit-breaking behavior.
+type ClientConfig struct {
+	// MaxRetries is how many additional attempts are made for a failed,
+	// idempotent (GET) request before giving up.
+	MaxRetries int
+	// RetryBaseDelay is the base delay used for exponential backoff
+	// between retries; actual delay includes jitter and, when present,
+	// honors a Retry-After response header.
+	RetryBaseDelay time.Duration
+	// RequestTimeout bounds a single HTTP round trip to Wavefront.
+	RequestTimeout time.Duration
+	// BreakerThreshold is the number of consecutive failures against an
+	// endpoint before its circuit breaker trips open.
+	BreakerThreshold int
+	// QueryDialect is the query language ExternalCfg's queries are
+	// authored in. PrometheusClient uses it to decide whether a query
+	// needs translating before it's sent; DefaultWavefrontClient ignores
+	// it, since Wavefront only ever speaks WQL.
+	QueryDialect QueryDialect
+}
+
+// DefaultClientConfig returns the ClientConfig used when the adapter is not
+// configured otherwise.
+func DefaultClientConfig() ClientConfig {
+	return ClientConfig{
+		MaxRetries:       2,
+		RetryBaseDelay:   500 * time.Millisecond,
+		RequestTimeout:   10 * time.Second,
+		BreakerThreshold: 5,
+		QueryDialect:     DialectWavefront,
+	}
+}
+
 type DefaultWavefrontClient struct {
-	baseURL *url.URL
-	token   string
+	baseURL     *url.URL
+	tokenSource TokenSource
+	cfg         ClientConfig
+	httpClient  *http.Client
+
+	eb *endpointBreakers
 }
 
-func NewWavefrontClient(baseURL *url.URL, token string) WavefrontClient {
+func NewWavefrontClient(baseURL *url.URL, tokenSource TokenSource, cfg ClientConfig) WavefrontClient {
 	return &DefaultWavefrontClient{
-		baseURL: baseURL,
-		token:   token,
+		baseURL:     baseURL,
+		tokenSource: tokenSource,
+		cfg:         cfg,
+		httpClient:  &http.Client{Timeout: cfg.RequestTimeout},
+		eb:          newEndpointBreakers(cfg),
 	}
 }
 
-var (
-	client = &http.Client{Timeout: time.Second * 10}
-)
-
 const (
 	authzHeader         = "Authorization"
 	bearer              = "Bearer "
@@ -46,7 +84,95 @@ const (
 	outsideSeries       = "i"
 )
 
-func (w DefaultWavefrontClient) Do(verb, endpoint string, query url.Values) (*http.Response, error) {
+func (w *DefaultWavefrontClient) Do(verb, endpoint string, query url.Values) (*http.Response, error) {
+	return w.eb.do(verb, endpoint, query, func() (*http.Response, error) {
+		return w.doWithAuthRetry(verb, endpoint, query)
+	})
+}
+
+// doWithAuthRetry sends a single logical request, forcing a token refresh
+// and retrying once if the response is a 401.
+func (w *DefaultWavefrontClient) doWithAuthRetry(verb, endpoint string, query url.Values) (*http.Response, error) {
+	resp, err := w.doOnce(verb, endpoint, query)
+	if resp == nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+
+	refresher, ok := w.tokenSource.(Refresher)
+	if !ok {
+		return resp, err
+	}
+
+	log.Debugf("DefaultWavefrontClient.Do: got 401 from %s, forcing token refresh and retrying", endpoint)
+	if _, rerr := refresher.Refresh(); rerr != nil {
+		log.Errorf("DefaultWavefrontClient.Do: failed to refresh wavefront token: %v", rerr)
+		return resp, err
+	}
+	return w.doOnce(verb, endpoint, query)
+}
+
+// isRetryable reports whether a request that produced resp/err failed in a
+// way that's worth retrying or counting against the circuit breaker: a
+// network-level error, or a 429/502/503 response.
+func isRetryable(resp *http.Response, err error) bool {
+	if resp == nil {
+		return err != nil
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable:
+		return true
+	default:
+		return false
+	}
+}
+
+// maxRetryBackoff caps the exponential backoff computed by retryDelay so
+// that a large --wavefront-max-retries during an extended outage can't grow
+// the delay past something operators would reasonably wait, and so the
+// shift below can't overflow time.Duration.
+const maxRetryBackoff = 2 * time.Minute
+
+// retryDelay computes the delay before the given retry attempt (1-indexed),
+// honoring a Retry-After header on 429/503 responses and otherwise using
+// exponential backoff with jitter based on base, capped at maxRetryBackoff.
+func retryDelay(base time.Duration, attempt int, resp *http.Response) time.Duration {
+	if resp != nil && (resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable) {
+		if d, ok := retryAfter(resp); ok {
+			return d
+		}
+	}
+
+	// Only shift base when the result stays within maxRetryBackoff, so the
+	// computation can't overflow time.Duration (an int64 count of
+	// nanoseconds) for any base or attempt.
+	shift := attempt - 1
+	if shift > 32 {
+		shift = 32
+	}
+	backoff := maxRetryBackoff
+	if base > 0 && base <= maxRetryBackoff>>uint(shift) {
+		backoff = base << uint(shift)
+	}
+	return backoff/2 + time.Duration(rand.Int63n(int64(backoff)/2+1))
+}
+
+// retryAfter parses a Retry-After response header, which is either a number
+// of seconds or an HTTP date.
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	v := resp.Header.Get("Retry-After")
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return time.Until(t), true
+	}
+	return 0, false
+}
+
+func (w *DefaultWavefrontClient) doOnce(verb, endpoint string, query url.Values) (*http.Response, error) {
 	u := *w.baseURL
 	u.Path = path.Join(u.Path, endpoint)
 	u.RawQuery = query.Encode()
@@ -58,9 +184,13 @@ func (w DefaultWavefrontClient) Do(verb, endpoint string, query url.Values) (*ht
 		return &http.Response{}, err
 	}
 
-	req.Header.Set(authzHeader, bearer+w.token)
+	token, err := w.tokenSource.Token()
+	if err != nil {
+		return &http.Response{}, fmt.Errorf("error getting wavefront token: %v", err)
+	}
+	req.Header.Set(authzHeader, bearer+token)
 
-	resp, err := client.Do(req)
+	resp, err := w.httpClient.Do(req)
 	if err != nil {
 		return resp, err
 	}
@@ -72,7 +202,7 @@ func (w DefaultWavefrontClient) Do(verb, endpoint string, query url.Values) (*ht
 	return resp, nil
 }
 
-func (w DefaultWavefrontClient) ListMetrics(prefix string) ([]string, error) {
+func (w *DefaultWavefrontClient) ListMetrics(prefix string) ([]string, error) {
 	log.Debugf("DefaultWavefrontClient.ListMetrics")
 
 	vals := url.Values{}
@@ -97,7 +227,7 @@ func (w DefaultWavefrontClient) ListMetrics(prefix string) ([]string, error) {
 	return result.Metrics, nil
 }
 
-func (w DefaultWavefrontClient) Query(start int64, query string) (QueryResult, error) {
+func (w *DefaultWavefrontClient) Query(start int64, query string) (QueryResult, error) {
 	log.Debugf("DefaultWavefrontClient.Query: start=%d, query=%s", start, query)
 	if query == "" {
 		return QueryResult{}, &Error{
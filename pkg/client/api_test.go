@@ -0,0 +1,106 @@
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestDoTripsBreakerOnPersistentNonRetryableError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	u, _ := url.Parse(srv.URL)
+	c := NewWavefrontClient(u, NewStaticTokenSource("tok"), ClientConfig{
+		MaxRetries:       0,
+		RetryBaseDelay:   time.Millisecond,
+		RequestTimeout:   time.Second,
+		BreakerThreshold: 2,
+	})
+
+	// A persistent 500 isn't "retryable" (isRetryable only covers
+	// network errors and 429/502/503), but it must still count as a
+	// breaker failure or the breaker would never trip against an
+	// endpoint that's simply broken.
+	for i := 0; i < 2; i++ {
+		if _, err := c.Do(http.MethodGet, "/x", url.Values{}); err == nil {
+			t.Fatalf("attempt %d: expected error from 500 response", i)
+		}
+	}
+
+	_, err := c.Do(http.MethodGet, "/x", url.Values{})
+	if err == nil {
+		t.Fatal("expected circuit breaker to be open after threshold failures")
+	}
+	if _, ok := err.(*CircuitOpenError); !ok {
+		t.Fatalf("expected CircuitOpenError, got %T: %v", err, err)
+	}
+}
+
+func TestDoTripsBreakerOnlyForTheOffendingQuery(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("q") == "ts(bad.query)" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("{}"))
+	}))
+	defer srv.Close()
+
+	u, _ := url.Parse(srv.URL)
+	c := NewWavefrontClient(u, NewStaticTokenSource("tok"), ClientConfig{
+		MaxRetries:       0,
+		RetryBaseDelay:   time.Millisecond,
+		RequestTimeout:   time.Second,
+		BreakerThreshold: 2,
+	})
+
+	badQuery := url.Values{"q": []string{"ts(bad.query)"}}
+	goodQuery := url.Values{"q": []string{"ts(good.query)"}}
+
+	// Trip the breaker for the bad query alone.
+	for i := 0; i < 2; i++ {
+		if _, err := c.Do(http.MethodGet, "/x", badQuery); err == nil {
+			t.Fatalf("attempt %d: expected error from 500 response", i)
+		}
+	}
+	if _, err := c.Do(http.MethodGet, "/x", badQuery); err == nil {
+		t.Fatal("expected the bad query's breaker to be open")
+	} else if _, ok := err.(*CircuitOpenError); !ok {
+		t.Fatalf("expected CircuitOpenError, got %T: %v", err, err)
+	}
+
+	// A different query against the same HTTP endpoint must be unaffected.
+	if _, err := c.Do(http.MethodGet, "/x", goodQuery); err != nil {
+		t.Fatalf("good query should not be blocked by the bad query's open breaker: %v", err)
+	}
+}
+
+func TestDoWithNegativeMaxRetriesDoesNotPanic(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("{}"))
+	}))
+	defer srv.Close()
+
+	u, _ := url.Parse(srv.URL)
+	c := NewWavefrontClient(u, NewStaticTokenSource("tok"), ClientConfig{
+		MaxRetries:       -1,
+		RetryBaseDelay:   time.Millisecond,
+		RequestTimeout:   time.Second,
+		BreakerThreshold: 5,
+	})
+
+	resp, err := c.Do(http.MethodGet, "/x", url.Values{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp == nil {
+		t.Fatal("expected a non-nil response")
+	}
+}
@@ -0,0 +1,226 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+)
+
+// breakerState is the state of a circuitBreaker.
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+// breakerResetTimeout is how long an open breaker waits before allowing a
+// single probe request through in the half-open state.
+const breakerResetTimeout = 30 * time.Second
+
+var breakerStateGauge = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Namespace: "wavefront_adapter",
+		Subsystem: "client",
+		Name:      "circuit_breaker_state",
+		Help:      "State of the per-endpoint Wavefront client circuit breaker (0=closed, 1=open, 2=half-open).",
+	},
+	[]string{"endpoint"},
+)
+
+func init() {
+	prometheus.MustRegister(breakerStateGauge)
+}
+
+// CircuitOpenError is returned by Query and ListMetrics when the circuit
+// breaker for their endpoint+query is open, short-circuiting the request
+// instead of sending it to Wavefront.
+type CircuitOpenError struct {
+	Endpoint string
+}
+
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("circuit breaker open for endpoint %s", e.Endpoint)
+}
+
+// circuitBreaker trips after threshold consecutive failures against a
+// single endpoint, rejecting further requests until resetTimeout has
+// elapsed. It then allows one probe request through (half-open); success
+// closes the breaker, failure re-opens it.
+type circuitBreaker struct {
+	endpoint     string
+	threshold    int
+	resetTimeout time.Duration
+
+	mu       sync.Mutex
+	state    breakerState
+	failures int
+	openedAt time.Time
+}
+
+func newCircuitBreaker(endpoint string, threshold int, resetTimeout time.Duration) *circuitBreaker {
+	return &circuitBreaker{
+		endpoint:     endpoint,
+		threshold:    threshold,
+		resetTimeout: resetTimeout,
+	}
+}
+
+// Allow reports whether a request should be sent. It transitions an open
+// breaker to half-open once resetTimeout has passed.
+func (b *circuitBreaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case breakerClosed:
+		return true
+	case breakerHalfOpen:
+		// The single probe request is already in flight.
+		return false
+	}
+	if time.Since(b.openedAt) < b.resetTimeout {
+		return false
+	}
+	b.setState(breakerHalfOpen)
+	return true
+}
+
+// Success records a successful request, closing the breaker.
+func (b *circuitBreaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures = 0
+	b.setState(breakerClosed)
+}
+
+// Failure records a failed request, tripping the breaker if threshold
+// consecutive failures have now been seen (or immediately, if the failing
+// request was the half-open probe).
+func (b *circuitBreaker) Failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == breakerHalfOpen {
+		b.openedAt = time.Now()
+		b.setState(breakerOpen)
+		return
+	}
+
+	b.failures++
+	if b.failures >= b.threshold {
+		b.openedAt = time.Now()
+		b.setState(breakerOpen)
+	}
+}
+
+// setState must be called with b.mu held.
+func (b *circuitBreaker) setState(s breakerState) {
+	b.state = s
+	breakerStateGauge.WithLabelValues(b.endpoint).Set(float64(s))
+}
+
+// endpointBreakers manages one circuitBreaker per endpoint+query and runs
+// calls through it with a shared retry/backoff policy. Every WavefrontClient
+// backend talks to an HTTP metrics store with the same failure modes, so
+// DefaultWavefrontClient and PrometheusClient both build one of these
+// instead of each reimplementing the retry loop and breaker bookkeeping.
+type endpointBreakers struct {
+	cfg ClientConfig
+
+	mu       sync.Mutex
+	breakers map[string]*circuitBreaker
+}
+
+func newEndpointBreakers(cfg ClientConfig) *endpointBreakers {
+	if cfg.MaxRetries < 0 {
+		log.Warnf("newEndpointBreakers: MaxRetries %d is invalid, using 0", cfg.MaxRetries)
+		cfg.MaxRetries = 0
+	}
+	return &endpointBreakers{cfg: cfg, breakers: make(map[string]*circuitBreaker)}
+}
+
+// breakerKey identifies the logical call a breaker guards: the HTTP
+// endpoint plus its rendered query parameters. Keying on the query as well
+// as the endpoint means a single persistently bad ExternalCfg query (a
+// typo'd WQL expression, a deleted metric, a bad tag filter) only trips the
+// breaker for that one query, instead of tripping a breaker shared by every
+// other HPA/external metric that happens to hit the same HTTP path.
+func breakerKey(endpoint string, query url.Values) string {
+	if len(query) == 0 {
+		return endpoint
+	}
+	return endpoint + "?" + query.Encode()
+}
+
+func (e *endpointBreakers) breakerFor(key string) *circuitBreaker {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	b, ok := e.breakers[key]
+	if !ok {
+		b = newCircuitBreaker(key, e.cfg.BreakerThreshold, breakerResetTimeout)
+		e.breakers[key] = b
+	}
+	return b
+}
+
+// isBreakerFailure reports whether a request that produced resp/err should
+// count against its circuit breaker: a network-level error, or any 5xx
+// response (isRetryable doesn't cover a plain, persistent 500, which is
+// still a backend-health signal). A 4xx response reflects a problem with
+// that specific request - a malformed or stale query, an unauthorized
+// token - not the health of the backend, so it's returned to the caller
+// without tripping the breaker.
+func isBreakerFailure(resp *http.Response, err error) bool {
+	if isRetryable(resp, err) {
+		return true
+	}
+	return resp != nil && resp.StatusCode >= 500
+}
+
+// do runs attempt, a single logical call to endpoint with query, under that
+// call's circuit breaker: it retries GET verbs per e.cfg with exponential
+// backoff and jitter (honoring Retry-After), and records the outcome
+// against the breaker based on isBreakerFailure, not on whether the error
+// looked worth retrying inline.
+func (e *endpointBreakers) do(verb, endpoint string, query url.Values, attempt func() (*http.Response, error)) (*http.Response, error) {
+	key := breakerKey(endpoint, query)
+	breaker := e.breakerFor(key)
+	if !breaker.Allow() {
+		return &http.Response{}, &CircuitOpenError{Endpoint: key}
+	}
+
+	maxAttempts := 1
+	if verb == http.MethodGet {
+		maxAttempts += e.cfg.MaxRetries
+	}
+
+	var resp *http.Response
+	var err error
+	for i := 1; i <= maxAttempts; i++ {
+		resp, err = attempt()
+		if !isRetryable(resp, err) || i == maxAttempts {
+			break
+		}
+
+		delay := retryDelay(e.cfg.RetryBaseDelay, i, resp)
+		log.Warnf("endpointBreakers.do: retrying %s after %v (attempt %d/%d): %v",
+			key, delay, i, maxAttempts-1, err)
+		time.Sleep(delay)
+	}
+
+	if isBreakerFailure(resp, err) {
+		breaker.Failure()
+	} else {
+		breaker.Success()
+	}
+	return resp, err
+}
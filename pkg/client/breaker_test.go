@@ -0,0 +1,105 @@
+package client
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreakerTripsAfterThreshold(t *testing.T) {
+	b := newCircuitBreaker("ep", 3, time.Hour)
+
+	for i := 0; i < 2; i++ {
+		if !b.Allow() {
+			t.Fatalf("expected breaker to allow request %d before tripping", i)
+		}
+		b.Failure()
+	}
+	if b.state != breakerClosed {
+		t.Fatalf("expected breaker still closed after 2/3 failures, got %v", b.state)
+	}
+
+	b.Failure()
+	if b.state != breakerOpen {
+		t.Fatalf("expected breaker open after 3/3 failures, got %v", b.state)
+	}
+	if b.Allow() {
+		t.Fatal("expected breaker to reject requests while open")
+	}
+}
+
+func TestCircuitBreakerHalfOpenRecovery(t *testing.T) {
+	b := newCircuitBreaker("ep", 1, 10*time.Millisecond)
+
+	b.Failure()
+	if b.Allow() {
+		t.Fatal("expected breaker to still be open immediately after tripping")
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	if !b.Allow() {
+		t.Fatal("expected breaker to allow a probe once resetTimeout has elapsed")
+	}
+	if b.state != breakerHalfOpen {
+		t.Fatalf("expected breaker half-open after probe is allowed, got %v", b.state)
+	}
+
+	b.Success()
+	if b.state != breakerClosed {
+		t.Fatalf("expected breaker closed after a successful probe, got %v", b.state)
+	}
+}
+
+func TestCircuitBreakerHalfOpenProbeFailureReopens(t *testing.T) {
+	b := newCircuitBreaker("ep", 1, 10*time.Millisecond)
+
+	b.Failure()
+	time.Sleep(20 * time.Millisecond)
+	if !b.Allow() {
+		t.Fatal("expected breaker to allow the half-open probe")
+	}
+
+	b.Failure()
+	if b.state != breakerOpen {
+		t.Fatalf("expected a failed probe to immediately reopen the breaker, got %v", b.state)
+	}
+}
+
+func TestBreakerKeyIncludesQuery(t *testing.T) {
+	a := breakerKey("/api/v2/chart/api", url.Values{"q": []string{"ts(metric.a)"}})
+	b := breakerKey("/api/v2/chart/api", url.Values{"q": []string{"ts(metric.b)"}})
+	if a == b {
+		t.Fatalf("expected different queries to produce different breaker keys, both were %q", a)
+	}
+}
+
+func TestBreakerKeyWithNoQueryIsJustEndpoint(t *testing.T) {
+	if got := breakerKey("/api/v2/chart/api", url.Values{}); got != "/api/v2/chart/api" {
+		t.Fatalf("got %q, want bare endpoint", got)
+	}
+}
+
+func TestIsBreakerFailureCountsNetworkErrorsAnd5xx(t *testing.T) {
+	cases := []struct {
+		name string
+		resp *http.Response
+		err  error
+		want bool
+	}{
+		{"network error, nil response", nil, errors.New("dial tcp: refused"), true},
+		{"persistent 500", &http.Response{StatusCode: http.StatusInternalServerError}, errors.New("error status=500"), true},
+		{"retryable 503", &http.Response{StatusCode: http.StatusServiceUnavailable}, errors.New("error status=503"), true},
+		{"bad request 400", &http.Response{StatusCode: http.StatusBadRequest}, errors.New("error status=400"), false},
+		{"not found 404", &http.Response{StatusCode: http.StatusNotFound}, errors.New("error status=404"), false},
+		{"success", &http.Response{StatusCode: http.StatusOK}, nil, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := isBreakerFailure(tc.resp, tc.err); got != tc.want {
+				t.Fatalf("isBreakerFailure() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
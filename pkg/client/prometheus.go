@@ -0,0 +1,216 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	promQueryEndpoint  = "/api/v1/query"
+	promSeriesEndpoint = "/api/v1/label/__name__/values"
+)
+
+// PrometheusClient is a WavefrontClient backed by a Prometheus-compatible
+// HTTP API (Prometheus itself, or a store exposing the same API such as
+// Thanos, Cortex or Mimir). It translates Query/ListMetrics calls into
+// /api/v1/query and /api/v1/label/__name__/values requests and decodes the
+// Prometheus response envelope into the adapter's QueryResult/ListResult
+// types, so it is a drop-in alternative to DefaultWavefrontClient behind the
+// same interface.
+type PrometheusClient struct {
+	baseURL     *url.URL
+	tokenSource TokenSource
+	cfg         ClientConfig
+	httpClient  *http.Client
+
+	eb *endpointBreakers
+}
+
+// NewPrometheusClient returns a WavefrontClient that queries the
+// Prometheus-compatible API at baseURL, authenticating with tokenSource if
+// it yields a non-empty token.
+func NewPrometheusClient(baseURL *url.URL, tokenSource TokenSource, cfg ClientConfig) WavefrontClient {
+	return &PrometheusClient{
+		baseURL:     baseURL,
+		tokenSource: tokenSource,
+		cfg:         cfg,
+		httpClient:  &http.Client{Timeout: cfg.RequestTimeout},
+		eb:          newEndpointBreakers(cfg),
+	}
+}
+
+// Do sends verb to endpoint, retrying transient GET failures and tripping
+// a per-endpoint+query circuit breaker via the endpointBreakers shared
+// with DefaultWavefrontClient, since the two backends share the same
+// failure modes against an HTTP metrics store.
+func (p *PrometheusClient) Do(verb, endpoint string, query url.Values) (*http.Response, error) {
+	return p.eb.do(verb, endpoint, query, func() (*http.Response, error) {
+		return p.doOnce(verb, endpoint, query)
+	})
+}
+
+func (p *PrometheusClient) doOnce(verb, endpoint string, query url.Values) (*http.Response, error) {
+	u := *p.baseURL
+	u.Path = path.Join(u.Path, endpoint)
+	u.RawQuery = query.Encode()
+
+	log.Debugf("PrometheusClient.Do, query: %s", u.String())
+
+	req, err := http.NewRequest(verb, u.String(), nil)
+	if err != nil {
+		return &http.Response{}, err
+	}
+
+	if p.tokenSource != nil {
+		if token, err := p.tokenSource.Token(); err == nil && token != "" {
+			req.Header.Set(authzHeader, bearer+token)
+		}
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return resp, err
+	}
+
+	code := resp.StatusCode
+	if code/100 != 2 {
+		return resp, fmt.Errorf("error status=%s code=%d", resp.Status, code)
+	}
+	return resp, nil
+}
+
+// promEnvelope is the common response wrapper for all Prometheus HTTP API
+// endpoints used here.
+type promEnvelope struct {
+	Status string          `json:"status"`
+	Data   json.RawMessage `json:"data"`
+	Error  string          `json:"error"`
+}
+
+// promVectorData is the `data` payload of a successful instant /api/v1/query
+// response with resultType "vector".
+type promVectorData struct {
+	ResultType string `json:"resultType"`
+	Result     []struct {
+		Metric map[string]string `json:"metric"`
+		Value  [2]interface{}    `json:"value"`
+	} `json:"result"`
+}
+
+// ListMetrics lists the metric names whose __name__ matches prefix, via
+// /api/v1/label/__name__/values.
+func (p *PrometheusClient) ListMetrics(prefix string) ([]string, error) {
+	log.Debugf("PrometheusClient.ListMetrics")
+
+	resp, err := p.Do(http.MethodGet, promSeriesEndpoint, url.Values{})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var env promEnvelope
+	if err := json.NewDecoder(resp.Body).Decode(&env); err != nil {
+		return nil, &Error{Type: ErrBadResponse, Msg: err.Error()}
+	}
+	if env.Status != "success" {
+		return nil, &Error{Type: ErrBadResponse, Msg: env.Error}
+	}
+
+	var names []string
+	if err := json.Unmarshal(env.Data, &names); err != nil {
+		return nil, &Error{Type: ErrBadResponse, Msg: err.Error()}
+	}
+
+	filtered := names[:0]
+	for _, n := range names {
+		if strings.HasPrefix(n, prefix) {
+			filtered = append(filtered, n)
+		}
+	}
+	return filtered, nil
+}
+
+// Query evaluates query as a PromQL instant query at time start, via
+// /api/v1/query. Unless cfg.QueryDialect is DialectPromQL, query is first
+// translated from WQL with TranslateWQLToPromQL.
+func (p *PrometheusClient) Query(start int64, query string) (QueryResult, error) {
+	log.Debugf("PrometheusClient.Query: start=%d, query=%s", start, query)
+	if query == "" {
+		return QueryResult{}, &Error{
+			Type: ErrBadData,
+			Msg:  "empty query string",
+		}
+	}
+
+	promQuery := query
+	if p.cfg.QueryDialect != DialectPromQL {
+		translated, err := TranslateWQLToPromQL(query)
+		if err != nil {
+			return QueryResult{}, &Error{Type: ErrBadData, Msg: err.Error()}
+		}
+		promQuery = translated
+	}
+
+	vals := url.Values{}
+	vals.Set("query", promQuery)
+	vals.Set("time", strconv.FormatInt(start, 10))
+
+	resp, err := p.Do(http.MethodGet, promQueryEndpoint, vals)
+	if err != nil {
+		return QueryResult{}, err
+	}
+	defer resp.Body.Close()
+
+	var env promEnvelope
+	if err := json.NewDecoder(resp.Body).Decode(&env); err != nil {
+		return QueryResult{}, &Error{Type: ErrBadResponse, Msg: err.Error()}
+	}
+	if env.Status != "success" {
+		return QueryResult{}, &Error{Type: ErrBadResponse, Msg: env.Error}
+	}
+
+	var vector promVectorData
+	if err := json.Unmarshal(env.Data, &vector); err != nil {
+		return QueryResult{}, &Error{Type: ErrBadResponse, Msg: err.Error()}
+	}
+
+	result := QueryResult{}
+	for _, sample := range vector.Result {
+		ts, val, ok := parsePromSample(sample.Value)
+		if !ok {
+			continue
+		}
+		result.Timeseries = append(result.Timeseries, Timeseries{
+			Label:      sample.Metric["__name__"],
+			Host:       sample.Metric["host"],
+			Tags:       sample.Metric,
+			DataPoints: [][]float64{{float64(ts), val}},
+		})
+	}
+	log.Trace("PrometheusClient.Query", result)
+	return result, nil
+}
+
+// parsePromSample decodes a Prometheus [timestamp, "value"] sample pair.
+func parsePromSample(value [2]interface{}) (int64, float64, bool) {
+	ts, ok := value[0].(float64)
+	if !ok {
+		return 0, 0, false
+	}
+	str, ok := value[1].(string)
+	if !ok {
+		return 0, 0, false
+	}
+	val, err := strconv.ParseFloat(str, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	return int64(ts), val, true
+}
@@ -0,0 +1,57 @@
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func newTestPrometheusClient(t *testing.T, cfg ClientConfig, handler http.HandlerFunc) (WavefrontClient, *string) {
+	t.Helper()
+	var gotQuery string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotQuery = r.URL.Query().Get("query")
+		handler(w, r)
+	}))
+	t.Cleanup(srv.Close)
+
+	u, _ := url.Parse(srv.URL)
+	cfg.MaxRetries = 0
+	cfg.RetryBaseDelay = time.Millisecond
+	cfg.RequestTimeout = time.Second
+	if cfg.BreakerThreshold == 0 {
+		cfg.BreakerThreshold = 5
+	}
+	return NewPrometheusClient(u, NewStaticTokenSource("tok"), cfg), &gotQuery
+}
+
+func TestQueryTranslatesWQLByDefault(t *testing.T) {
+	c, gotQuery := newTestPrometheusClient(t, ClientConfig{}, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[]}}`))
+	})
+
+	if _, err := c.Query(0, "ts(kubernetes.pod.cpu.usage_rate, namespace=default)"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := `kubernetes_pod_cpu_usage_rate{namespace="default"}`; *gotQuery != want {
+		t.Fatalf("expected translated query %q, got %q", want, *gotQuery)
+	}
+}
+
+func TestQuerySkipsTranslationWhenDialectIsPromQL(t *testing.T) {
+	c, gotQuery := newTestPrometheusClient(t, ClientConfig{QueryDialect: DialectPromQL}, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[]}}`))
+	})
+
+	const raw = `kubernetes_pod_cpu_usage_rate{namespace="default"}`
+	if _, err := c.Query(0, raw); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if *gotQuery != raw {
+		t.Fatalf("expected untranslated query %q to be sent unchanged, got %q", raw, *gotQuery)
+	}
+}
@@ -0,0 +1,69 @@
+package client
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// QueryDialect names the query language ExternalCfg's queries are authored
+// in, so a PrometheusClient knows whether it needs to translate them before
+// sending them to a Prometheus-compatible --metrics-backend.
+type QueryDialect string
+
+const (
+	// DialectWavefront is the Wavefront Query Language dialect: metric
+	// names are dot-delimited (e.g. kubernetes.pod.cpu.usage_rate) and
+	// ts(metric, tag=value ...) selects by tag. This is the default,
+	// since it's the dialect the adapter's own examples and existing
+	// ExternalCfg deployments are authored in.
+	DialectWavefront QueryDialect = "wavefront"
+	// DialectPromQL is the PromQL dialect: metric names are
+	// underscore-delimited and labels are matched with {key="value"}.
+	// Use this when ExternalCfg's queries were already hand-written for
+	// the Prometheus-compatible backend, so PrometheusClient sends them
+	// through unmodified instead of attempting to parse them as ts(...).
+	DialectPromQL QueryDialect = "promql"
+)
+
+// wqlTsExpr matches a Wavefront Query Language ts() call: a dot-delimited
+// metric name optionally followed by a comma and tag=value/tag="value"
+// filters joined with " and ", e.g.
+//
+//	ts(kubernetes.pod.cpu.usage_rate, namespace=default and pod_name=foo)
+var wqlTsExpr = regexp.MustCompile(`(?i)^\s*ts\(\s*([a-zA-Z0-9_.]+)\s*(?:,\s*(.+?)\s*)?\)\s*$`)
+
+// wqlTagFilter matches a single `tag=value` or `tag="value"` clause within
+// a ts() filter list.
+var wqlTagFilter = regexp.MustCompile(`^([a-zA-Z0-9_.]+)\s*=\s*"?([^"]*?)"?$`)
+
+// TranslateWQLToPromQL rewrites a Wavefront Query Language expression into
+// the equivalent PromQL instant-vector selector, so ExternalCfg queries
+// authored against Wavefront can be sent to a Prometheus-compatible
+// --metrics-backend unchanged. Only the ts(metric[, filters]) form is
+// supported, since it's the form the adapter itself generates and the one
+// documented for ExternalCfg; anything else is returned unchanged on the
+// assumption it's already PromQL.
+func TranslateWQLToPromQL(query string) (string, error) {
+	m := wqlTsExpr.FindStringSubmatch(query)
+	if m == nil {
+		return query, nil
+	}
+
+	metric := strings.ReplaceAll(m[1], ".", "_")
+	filterExpr := strings.TrimSpace(m[2])
+	if filterExpr == "" {
+		return metric, nil
+	}
+
+	clauses := strings.Split(filterExpr, " and ")
+	labels := make([]string, 0, len(clauses))
+	for _, clause := range clauses {
+		fm := wqlTagFilter.FindStringSubmatch(strings.TrimSpace(clause))
+		if fm == nil {
+			return "", fmt.Errorf("translate wql to promql: cannot parse filter %q in query %q", clause, query)
+		}
+		labels = append(labels, fmt.Sprintf(`%s="%s"`, fm[1], fm[2]))
+	}
+	return fmt.Sprintf("%s{%s}", metric, strings.Join(labels, ",")), nil
+}
@@ -0,0 +1,50 @@
+package client
+
+import "testing"
+
+func TestTranslateWQLToPromQLBareMetric(t *testing.T) {
+	got, err := TranslateWQLToPromQL("ts(kubernetes.pod.cpu.usage_rate)")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := "kubernetes_pod_cpu_usage_rate"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestTranslateWQLToPromQLWithFilters(t *testing.T) {
+	got, err := TranslateWQLToPromQL(`ts(kubernetes.pod.cpu.usage_rate, namespace=default and pod_name=foo)`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := `kubernetes_pod_cpu_usage_rate{namespace="default",pod_name="foo"}`
+	if got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestTranslateWQLToPromQLWithQuotedFilterValue(t *testing.T) {
+	got, err := TranslateWQLToPromQL(`ts(my.metric, source="host-1")`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := `my_metric{source="host-1"}`; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestTranslateWQLToPromQLPassesThroughNonWQL(t *testing.T) {
+	got, err := TranslateWQLToPromQL(`my_metric{namespace="default"}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := `my_metric{namespace="default"}`; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestTranslateWQLToPromQLRejectsUnparsableFilter(t *testing.T) {
+	if _, err := TranslateWQLToPromQL("ts(my.metric, not a filter)"); err == nil {
+		t.Fatal("expected error for unparsable filter clause")
+	}
+}
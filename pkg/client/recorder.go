@@ -0,0 +1,116 @@
+package client
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+	yaml "gopkg.in/yaml.v2"
+)
+
+var dryRunCallsTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: "wavefront_adapter",
+		Subsystem: "client",
+		Name:      "dry_run_calls_total",
+		Help:      "Number of Query/ListMetrics calls recorded by the dry-run client instead of being sent to a live backend.",
+	},
+	[]string{"endpoint"},
+)
+
+func init() {
+	prometheus.MustRegister(dryRunCallsTotal)
+}
+
+// Fixtures holds the canned responses a RecordingClient returns instead of
+// contacting a live metrics backend, loaded from the file named by
+// --dry-run-fixtures.
+type Fixtures struct {
+	// ListMetrics maps a metric-name prefix to the metric names
+	// ListMetrics should return for it.
+	ListMetrics map[string][]string `yaml:"listMetrics"`
+	// Queries maps a query string to the QueryResult Query should return
+	// for it.
+	Queries map[string]QueryResult `yaml:"queries"`
+}
+
+// LoadFixtures reads and parses a Fixtures file. An empty path yields empty
+// Fixtures, so --dry-run works without --dry-run-fixtures.
+func LoadFixtures(path string) (*Fixtures, error) {
+	if path == "" {
+		return &Fixtures{}, nil
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var fixtures Fixtures
+	if err := yaml.Unmarshal(data, &fixtures); err != nil {
+		return nil, &Error{Type: ErrBadData, Msg: err.Error()}
+	}
+	return &fixtures, nil
+}
+
+// RecordingClient is a WavefrontClient used for --dry-run: instead of
+// contacting a live backend, it logs the fully-rendered URL each Query or
+// ListMetrics call would have made and returns a deterministic synthetic
+// result from Fixtures, so an operator can validate an ExternalCfg/HPA
+// setup without a live Wavefront tenant or API token. The logged URLs use
+// the Wavefront API's endpoints and parameters whichever --metrics-backend
+// is configured.
+type RecordingClient struct {
+	baseURL  *url.URL
+	fixtures *Fixtures
+}
+
+// NewRecordingClient returns a RecordingClient that renders requests
+// against baseURL (for logging only) and serves responses from fixtures.
+func NewRecordingClient(baseURL *url.URL, fixtures *Fixtures) WavefrontClient {
+	return &RecordingClient{baseURL: baseURL, fixtures: fixtures}
+}
+
+// render builds the fully-qualified URL a live call to endpoint/query would
+// have made, for logging.
+func (r *RecordingClient) render(endpoint string, query url.Values) string {
+	u := *r.baseURL
+	u.Path = path.Join(u.Path, endpoint)
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// Do logs the request that would have been made and returns an empty,
+// successful response; RecordingClient's Query and ListMetrics don't call
+// through Do, so this only matters for callers that use Do directly.
+func (r *RecordingClient) Do(verb, endpoint string, query url.Values) (*http.Response, error) {
+	log.Infof("RecordingClient.Do (dry-run): %s %s", verb, r.render(endpoint, query))
+	dryRunCallsTotal.WithLabelValues(endpoint).Inc()
+	return &http.Response{StatusCode: http.StatusOK}, nil
+}
+
+func (r *RecordingClient) ListMetrics(prefix string) ([]string, error) {
+	vals := url.Values{}
+	vals.Set("m", prefix)
+	vals.Set("l", "150")
+	log.Infof("RecordingClient.ListMetrics (dry-run): %s", r.render(metricsListEndpoint, vals))
+	dryRunCallsTotal.WithLabelValues(metricsListEndpoint).Inc()
+
+	return r.fixtures.ListMetrics[prefix], nil
+}
+
+func (r *RecordingClient) Query(start int64, query string) (QueryResult, error) {
+	vals := url.Values{}
+	vals.Set(queryKey, query)
+	vals.Set(startTime, strconv.FormatInt(start, 10))
+	vals.Set(granularity, "m")
+	vals.Set(outsideSeries, "false")
+	log.Infof("RecordingClient.Query (dry-run): %s", r.render(chartEndpoint, vals))
+	dryRunCallsTotal.WithLabelValues(chartEndpoint).Inc()
+
+	return r.fixtures.Queries[query], nil
+}
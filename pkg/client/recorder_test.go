@@ -0,0 +1,109 @@
+package client
+
+import (
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadFixturesEmptyPathYieldsEmptyFixtures(t *testing.T) {
+	f, err := LoadFixtures("")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if f == nil || len(f.ListMetrics) != 0 || len(f.Queries) != 0 {
+		t.Fatalf("expected empty Fixtures, got %+v", f)
+	}
+}
+
+func TestLoadFixturesParsesYAML(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "fixtures.yaml")
+	contents := `
+listMetrics:
+  kubernetes.pod:
+    - kubernetes.pod.cpu.usage_rate
+queries:
+  ts(kubernetes.pod.cpu.usage_rate):
+    timeseries: []
+`
+	if err := ioutil.WriteFile(path, []byte(contents), 0600); err != nil {
+		t.Fatalf("writing fixture file: %v", err)
+	}
+
+	f, err := LoadFixtures(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := f.ListMetrics["kubernetes.pod"]; len(got) != 1 || got[0] != "kubernetes.pod.cpu.usage_rate" {
+		t.Fatalf("unexpected ListMetrics fixtures: %+v", f.ListMetrics)
+	}
+	if _, ok := f.Queries["ts(kubernetes.pod.cpu.usage_rate)"]; !ok {
+		t.Fatalf("expected fixture query to be present, got %+v", f.Queries)
+	}
+}
+
+func TestLoadFixturesMissingFileReturnsError(t *testing.T) {
+	if _, err := LoadFixtures(filepath.Join(os.TempDir(), "does-not-exist-fixtures.yaml")); err == nil {
+		t.Fatal("expected error for missing fixtures file")
+	}
+}
+
+func TestRecordingClientListMetricsReturnsFixture(t *testing.T) {
+	u, _ := url.Parse("https://example.wavefront.com")
+	c := NewRecordingClient(u, &Fixtures{
+		ListMetrics: map[string][]string{"kubernetes.pod": {"kubernetes.pod.cpu.usage_rate"}},
+	})
+
+	got, err := c.ListMetrics("kubernetes.pod")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 1 || got[0] != "kubernetes.pod.cpu.usage_rate" {
+		t.Fatalf("got %v, want [kubernetes.pod.cpu.usage_rate]", got)
+	}
+}
+
+func TestRecordingClientListMetricsUnknownPrefixReturnsEmpty(t *testing.T) {
+	u, _ := url.Parse("https://example.wavefront.com")
+	c := NewRecordingClient(u, &Fixtures{})
+
+	got, err := c.ListMetrics("unknown")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 0 {
+		t.Fatalf("got %v, want empty", got)
+	}
+}
+
+func TestRecordingClientQueryReturnsFixture(t *testing.T) {
+	u, _ := url.Parse("https://example.wavefront.com")
+	want := QueryResult{Timeseries: []Timeseries{{Label: "kubernetes.pod.cpu.usage_rate"}}}
+	c := NewRecordingClient(u, &Fixtures{
+		Queries: map[string]QueryResult{"ts(kubernetes.pod.cpu.usage_rate)": want},
+	})
+
+	got, err := c.Query(0, "ts(kubernetes.pod.cpu.usage_rate)")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got.Timeseries) != 1 || got.Timeseries[0].Label != "kubernetes.pod.cpu.usage_rate" {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestRecordingClientDoReturnsOKWithoutContactingABackend(t *testing.T) {
+	u, _ := url.Parse("https://example.wavefront.com")
+	c := NewRecordingClient(u, &Fixtures{})
+
+	resp, err := c.Do("GET", "/chart/metrics/list", url.Values{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.StatusCode != 200 {
+		t.Fatalf("got status %d, want 200", resp.StatusCode)
+	}
+}
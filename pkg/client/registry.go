@@ -0,0 +1,38 @@
+package client
+
+import "net/url"
+
+// BackendConfig bundles the inputs needed to construct any WavefrontClient
+// backend, regardless of which metrics store it talks to.
+type BackendConfig struct {
+	BaseURL      *url.URL
+	TokenSource  TokenSource
+	ClientConfig ClientConfig
+}
+
+// backendConstructors maps a --metrics-backend name to the WavefrontClient
+// implementation it selects.
+var backendConstructors = map[string]func(BackendConfig) WavefrontClient{
+	"wavefront": func(cfg BackendConfig) WavefrontClient {
+		return NewWavefrontClient(cfg.BaseURL, cfg.TokenSource, cfg.ClientConfig)
+	},
+	"prometheus": func(cfg BackendConfig) WavefrontClient {
+		return NewPrometheusClient(cfg.BaseURL, cfg.TokenSource, cfg.ClientConfig)
+	},
+}
+
+// NewClient constructs the WavefrontClient-compatible backend named by
+// backend, so the adapter can be pointed at Wavefront or a
+// Prometheus-compatible store (Thanos, Cortex, Mimir) without changing any
+// other wiring. It returns an error if backend isn't one of the registered
+// names.
+func NewClient(backend string, cfg BackendConfig) (WavefrontClient, error) {
+	ctor, ok := backendConstructors[backend]
+	if !ok {
+		return nil, &Error{
+			Type: ErrBadData,
+			Msg:  "unknown metrics backend " + backend + ", expected one of \"wavefront\" or \"prometheus\"",
+		}
+	}
+	return ctor(cfg), nil
+}
@@ -0,0 +1,39 @@
+package client
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestRetryDelayDoesNotOverflowOrPanic(t *testing.T) {
+	for attempt := 1; attempt <= 100; attempt++ {
+		d := retryDelay(500*time.Millisecond, attempt, nil)
+		if d <= 0 {
+			t.Fatalf("attempt %d: retryDelay returned non-positive duration %v", attempt, d)
+		}
+		if d > maxRetryBackoff {
+			t.Fatalf("attempt %d: retryDelay %v exceeds cap %v", attempt, d, maxRetryBackoff)
+		}
+	}
+}
+
+func TestRetryDelayHonorsRetryAfterSeconds(t *testing.T) {
+	resp := &http.Response{
+		StatusCode: http.StatusTooManyRequests,
+		Header:     http.Header{"Retry-After": []string{"5"}},
+	}
+	if d := retryDelay(time.Second, 1, resp); d != 5*time.Second {
+		t.Fatalf("expected 5s, got %v", d)
+	}
+}
+
+func TestRetryDelayIgnoresRetryAfterOnOtherStatuses(t *testing.T) {
+	resp := &http.Response{
+		StatusCode: http.StatusInternalServerError,
+		Header:     http.Header{"Retry-After": []string{"5"}},
+	}
+	if d := retryDelay(time.Second, 1, resp); d == 5*time.Second {
+		t.Fatalf("Retry-After should only be honored for 429/503, got %v", d)
+	}
+}
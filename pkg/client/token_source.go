@@ -0,0 +1,194 @@
+package client
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+	log "github.com/sirupsen/logrus"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// TokenSource supplies the Wavefront API token used to authenticate
+// requests. Implementations may change the value they return over time,
+// e.g. when the token is rotated on disk or in a Kubernetes Secret.
+type TokenSource interface {
+	// Token returns the current API token.
+	Token() (string, error)
+}
+
+// Refresher is implemented by TokenSources that can force an out-of-band
+// reload of their underlying token, bypassing any cached value. It is used
+// to recover from a token that was rotated but not yet observed.
+type Refresher interface {
+	Refresh() (string, error)
+}
+
+type staticTokenSource struct {
+	token string
+}
+
+// NewStaticTokenSource returns a TokenSource that always returns the given
+// token, mirroring the adapter's original behavior of passing --wavefront-token
+// straight through.
+func NewStaticTokenSource(token string) TokenSource {
+	return &staticTokenSource{token: token}
+}
+
+func (s *staticTokenSource) Token() (string, error) {
+	return s.token, nil
+}
+
+type fileTokenSource struct {
+	path string
+
+	mu    sync.RWMutex
+	token string
+}
+
+// NewFileTokenSource returns a TokenSource backed by the file at path. The
+// file is read immediately and again whenever it changes on disk, so the
+// Wavefront API token can be rotated by updating a mounted secret without
+// restarting the adapter pod.
+func NewFileTokenSource(path string) (TokenSource, error) {
+	f := &fileTokenSource{path: path}
+	if err := f.reload(); err != nil {
+		return nil, err
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	if err := watcher.Add(filepath.Dir(path)); err != nil {
+		watcher.Close()
+		return nil, err
+	}
+	go f.watch(watcher)
+
+	return f, nil
+}
+
+func (f *fileTokenSource) watch(watcher *fsnotify.Watcher) {
+	defer watcher.Close()
+
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			// A mounted Kubernetes Secret is updated by swapping the
+			// directory's ..data symlink, which produces no event naming
+			// the token file itself.
+			name := filepath.Clean(event.Name)
+			if name != filepath.Clean(f.path) && filepath.Base(name) != "..data" {
+				continue
+			}
+			if event.Op&(fsnotify.Write|fsnotify.Create) == 0 {
+				continue
+			}
+			if err := f.reload(); err != nil {
+				log.Errorf("fileTokenSource: failed to reload wavefront token from %s: %v", f.path, err)
+			} else {
+				log.Infof("fileTokenSource: reloaded wavefront token from %s", f.path)
+			}
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Errorf("fileTokenSource: error watching %s: %v", f.path, err)
+		}
+	}
+}
+
+func (f *fileTokenSource) reload() error {
+	data, err := ioutil.ReadFile(f.path)
+	if err != nil {
+		return err
+	}
+	f.mu.Lock()
+	f.token = strings.TrimSpace(string(data))
+	f.mu.Unlock()
+	return nil
+}
+
+func (f *fileTokenSource) Token() (string, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	if f.token == "" {
+		return "", &Error{Type: ErrBadData, Msg: "wavefront token file " + f.path + " is empty"}
+	}
+	return f.token, nil
+}
+
+func (f *fileTokenSource) Refresh() (string, error) {
+	if err := f.reload(); err != nil {
+		return "", err
+	}
+	return f.Token()
+}
+
+type secretTokenSource struct {
+	kubeClient kubernetes.Interface
+	namespace  string
+	name       string
+	key        string
+
+	mu    sync.RWMutex
+	token string
+}
+
+// NewSecretTokenSource returns a TokenSource backed by the given key of a
+// Kubernetes Secret, resolved through kubeClient. The secret is read on the
+// first call to Token and again whenever Refresh is called, e.g. after a 401
+// that suggests the cached token is stale.
+func NewSecretTokenSource(kubeClient kubernetes.Interface, namespace, name, key string) TokenSource {
+	return &secretTokenSource{
+		kubeClient: kubeClient,
+		namespace:  namespace,
+		name:       name,
+		key:        key,
+	}
+}
+
+func (s *secretTokenSource) fetch() (string, error) {
+	secret, err := s.kubeClient.CoreV1().Secrets(s.namespace).Get(context.TODO(), s.name, metav1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+	data, ok := secret.Data[s.key]
+	if !ok {
+		return "", &Error{
+			Type: ErrBadData,
+			Msg:  "secret " + s.namespace + "/" + s.name + " has no key " + s.key,
+		}
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+func (s *secretTokenSource) Token() (string, error) {
+	s.mu.RLock()
+	token := s.token
+	s.mu.RUnlock()
+	if token != "" {
+		return token, nil
+	}
+	return s.Refresh()
+}
+
+func (s *secretTokenSource) Refresh() (string, error) {
+	token, err := s.fetch()
+	if err != nil {
+		return "", err
+	}
+	s.mu.Lock()
+	s.token = token
+	s.mu.Unlock()
+	return token, nil
+}
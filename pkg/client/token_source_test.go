@@ -0,0 +1,149 @@
+package client
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestStaticTokenSourceReturnsConfiguredToken(t *testing.T) {
+	s := NewStaticTokenSource("tok")
+	got, err := s.Token()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "tok" {
+		t.Fatalf("got %q, want %q", got, "tok")
+	}
+}
+
+func TestFileTokenSourceReadsInitialToken(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "token")
+	if err := ioutil.WriteFile(path, []byte("initial-token\n"), 0600); err != nil {
+		t.Fatalf("writing token file: %v", err)
+	}
+
+	s, err := NewFileTokenSource(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got, err := s.Token()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "initial-token" {
+		t.Fatalf("got %q, want %q", got, "initial-token")
+	}
+}
+
+func TestFileTokenSourceEmptyFileReturnsError(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "token")
+	if err := ioutil.WriteFile(path, []byte(""), 0600); err != nil {
+		t.Fatalf("writing token file: %v", err)
+	}
+
+	s, err := NewFileTokenSource(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := s.Token(); err == nil {
+		t.Fatal("expected error for empty token file")
+	}
+}
+
+func TestFileTokenSourceRefreshPicksUpRotatedToken(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "token")
+	if err := ioutil.WriteFile(path, []byte("old-token"), 0600); err != nil {
+		t.Fatalf("writing token file: %v", err)
+	}
+
+	s, err := NewFileTokenSource(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := ioutil.WriteFile(path, []byte("new-token"), 0600); err != nil {
+		t.Fatalf("rewriting token file: %v", err)
+	}
+
+	refresher, ok := s.(Refresher)
+	if !ok {
+		t.Fatal("fileTokenSource must implement Refresher")
+	}
+	got, err := refresher.Refresh()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "new-token" {
+		t.Fatalf("got %q, want %q", got, "new-token")
+	}
+}
+
+func TestFileTokenSourceMissingFileReturnsError(t *testing.T) {
+	if _, err := NewFileTokenSource(filepath.Join(os.TempDir(), "does-not-exist-token")); err == nil {
+		t.Fatal("expected error for missing token file")
+	}
+}
+
+func newTestSecret(namespace, name, key, value string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Data:       map[string][]byte{key: []byte(value)},
+	}
+}
+
+func TestSecretTokenSourceFetchesOnFirstToken(t *testing.T) {
+	kubeClient := fake.NewSimpleClientset(newTestSecret("ns", "wavefront-token", "token", "sekrit"))
+	s := NewSecretTokenSource(kubeClient, "ns", "wavefront-token", "token")
+
+	got, err := s.Token()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "sekrit" {
+		t.Fatalf("got %q, want %q", got, "sekrit")
+	}
+}
+
+func TestSecretTokenSourceMissingKeyReturnsError(t *testing.T) {
+	kubeClient := fake.NewSimpleClientset(newTestSecret("ns", "wavefront-token", "other-key", "sekrit"))
+	s := NewSecretTokenSource(kubeClient, "ns", "wavefront-token", "token")
+
+	if _, err := s.Token(); err == nil {
+		t.Fatal("expected error for missing secret key")
+	}
+}
+
+func TestSecretTokenSourceRefreshPicksUpRotatedSecret(t *testing.T) {
+	kubeClient := fake.NewSimpleClientset(newTestSecret("ns", "wavefront-token", "token", "old-token"))
+	s := NewSecretTokenSource(kubeClient, "ns", "wavefront-token", "token")
+
+	if _, err := s.Token(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	updated := newTestSecret("ns", "wavefront-token", "token", "new-token")
+	if _, err := kubeClient.CoreV1().Secrets("ns").Update(context.TODO(), updated, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("updating secret: %v", err)
+	}
+
+	refresher := s.(Refresher)
+	got, err := refresher.Refresh()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "new-token" {
+		t.Fatalf("got %q, want %q", got, "new-token")
+	}
+}
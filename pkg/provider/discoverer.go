@@ -0,0 +1,222 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provider
+
+import (
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/wavefronthq/wavefront-kubernetes-adapter/pkg/client"
+)
+
+// DeltaType identifies whether a DiscoveryDelta adds or removes a metric
+// from the provider's registration.
+type DeltaType int
+
+const (
+	// Added indicates a metric name that is newly known at a prefix.
+	Added DeltaType = iota
+	// Deleted indicates a metric name that is no longer present at a prefix.
+	Deleted
+)
+
+// DiscoveryDelta describes a single metric name appearing or disappearing
+// under a prefix, as observed by a MetricDiscoverer relist.
+type DiscoveryDelta struct {
+	Type   DeltaType
+	Prefix string
+	Metric string
+}
+
+// DiscoverySink receives the deltas produced by a relist so it can apply
+// them to the provider's custom-metric registration map incrementally,
+// instead of rebuilding the map from a full list every time.
+type DiscoverySink interface {
+	OnMetricDeltas(deltas []DiscoveryDelta)
+}
+
+// DiscoverySinkFunc adapts a function to a DiscoverySink.
+type DiscoverySinkFunc func(deltas []DiscoveryDelta)
+
+func (f DiscoverySinkFunc) OnMetricDeltas(deltas []DiscoveryDelta) {
+	f(deltas)
+}
+
+// DiscovererConfig holds the tunables for a MetricDiscoverer's hot and cold
+// relist loops.
+type DiscovererConfig struct {
+	// HotInterval is how often prefixes marked hot (referenced by an
+	// active HPA) are relisted.
+	HotInterval time.Duration
+	// ColdInterval is how often all other known prefixes are relisted.
+	ColdInterval time.Duration
+}
+
+// DefaultDiscovererConfig returns the DiscovererConfig used when the adapter
+// is not configured otherwise.
+func DefaultDiscovererConfig() DiscovererConfig {
+	return DiscovererConfig{
+		HotInterval:  30 * time.Second,
+		ColdInterval: 10 * time.Minute,
+	}
+}
+
+// metricSet is the set of metric names last observed at a single prefix.
+type metricSet map[string]struct{}
+
+// MetricDiscoverer maintains a local store of known Wavefront metric names
+// keyed by prefix, modeled on the client-go reflector/DeltaFIFO pattern: each
+// relist issues a narrow ListMetrics call scoped to one prefix, diffs the
+// result against the store, and emits incremental Added/Deleted deltas
+// instead of forcing the caller to recompute its whole registration map.
+//
+// Prefixes referenced by an active HPA are relisted on a short "hot"
+// interval so new metrics surface in seconds; all other known prefixes fall
+// back to a slower "cold" interval so the /chart/metrics/list endpoint
+// isn't hammered.
+type MetricDiscoverer struct {
+	waveClient client.WavefrontClient
+	sink       DiscoverySink
+	cfg        DiscovererConfig
+
+	mu    sync.Mutex
+	store map[string]metricSet // prefix -> known metric names
+	hot   map[string]struct{}  // prefix -> referenced by an active HPA
+}
+
+// NewMetricDiscoverer returns a MetricDiscoverer that relists waveClient and
+// reports incremental deltas to sink.
+func NewMetricDiscoverer(waveClient client.WavefrontClient, sink DiscoverySink, cfg DiscovererConfig) *MetricDiscoverer {
+	return &MetricDiscoverer{
+		waveClient: waveClient,
+		sink:       sink,
+		cfg:        cfg,
+		store:      make(map[string]metricSet),
+		hot:        make(map[string]struct{}),
+	}
+}
+
+// MarkHot records that prefix is referenced by an active HPA and should be
+// relisted on the hot interval. The prefix is marked hot whether or not
+// Relist has seen it yet.
+func (d *MetricDiscoverer) MarkHot(prefix string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.hot[prefix] = struct{}{}
+}
+
+// UnmarkHot stops relisting prefix on the hot interval, e.g. once no HPA
+// references it any longer.
+func (d *MetricDiscoverer) UnmarkHot(prefix string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.hot, prefix)
+}
+
+// Run starts the hot and cold relist loops. It blocks until stopCh is
+// closed, so callers should run it in its own goroutine.
+func (d *MetricDiscoverer) Run(stopCh <-chan struct{}) {
+	hotTicker := time.NewTicker(d.cfg.HotInterval)
+	defer hotTicker.Stop()
+	coldTicker := time.NewTicker(d.cfg.ColdInterval)
+	defer coldTicker.Stop()
+
+	for {
+		select {
+		case <-stopCh:
+			return
+		case <-hotTicker.C:
+			for _, prefix := range d.hotPrefixes() {
+				d.relist(prefix)
+			}
+		case <-coldTicker.C:
+			for _, prefix := range d.knownPrefixes() {
+				d.relist(prefix)
+			}
+		}
+	}
+}
+
+// Relist fetches and diffs prefix immediately, registering it for future
+// cold-loop relists if it hasn't been seen before. Callers use this to seed
+// discovery for a prefix referenced by a newly-created HPA before the next
+// tick.
+func (d *MetricDiscoverer) Relist(prefix string) {
+	d.relist(prefix)
+}
+
+func (d *MetricDiscoverer) hotPrefixes() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	prefixes := make([]string, 0, len(d.hot))
+	for p := range d.hot {
+		prefixes = append(prefixes, p)
+	}
+	return prefixes
+}
+
+func (d *MetricDiscoverer) knownPrefixes() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	prefixes := make([]string, 0, len(d.store))
+	for p := range d.store {
+		prefixes = append(prefixes, p)
+	}
+	return prefixes
+}
+
+// relist issues a single ListMetrics call scoped to prefix, diffs the result
+// against the store and reports the resulting deltas to the sink.
+func (d *MetricDiscoverer) relist(prefix string) {
+	names, err := d.waveClient.ListMetrics(prefix)
+	if err != nil {
+		log.Errorf("MetricDiscoverer.relist: failed to list metrics for prefix %s: %v", prefix, err)
+		return
+	}
+
+	seen := make(metricSet, len(names))
+	for _, n := range names {
+		seen[n] = struct{}{}
+	}
+
+	d.mu.Lock()
+	known, ok := d.store[prefix]
+	if !ok {
+		known = make(metricSet)
+	}
+
+	var deltas []DiscoveryDelta
+	for n := range seen {
+		if _, ok := known[n]; !ok {
+			deltas = append(deltas, DiscoveryDelta{Type: Added, Prefix: prefix, Metric: n})
+		}
+	}
+	for n := range known {
+		if _, ok := seen[n]; !ok {
+			deltas = append(deltas, DiscoveryDelta{Type: Deleted, Prefix: prefix, Metric: n})
+		}
+	}
+	d.store[prefix] = seen
+	d.mu.Unlock()
+
+	if len(deltas) > 0 {
+		log.Debugf("MetricDiscoverer.relist: prefix %s: %d delta(s)", prefix, len(deltas))
+		d.sink.OnMetricDeltas(deltas)
+	}
+}
@@ -0,0 +1,110 @@
+package provider
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/wavefronthq/wavefront-kubernetes-adapter/pkg/client"
+)
+
+// fakeListMetricsClient implements just enough of client.WavefrontClient for
+// MetricDiscoverer, which only ever calls ListMetrics.
+type fakeListMetricsClient struct {
+	client.WavefrontClient
+	metrics []string
+}
+
+func (f *fakeListMetricsClient) ListMetrics(prefix string) ([]string, error) {
+	return f.metrics, nil
+}
+
+func collectDeltas(d *MetricDiscoverer, prefix string) []DiscoveryDelta {
+	var got []DiscoveryDelta
+	d.sink = DiscoverySinkFunc(func(deltas []DiscoveryDelta) {
+		got = append(got, deltas...)
+	})
+	d.Relist(prefix)
+	return got
+}
+
+func sortedMetrics(deltas []DiscoveryDelta) []string {
+	names := make([]string, 0, len(deltas))
+	for _, d := range deltas {
+		names = append(names, d.Metric)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestMetricDiscovererRelistReportsAddedOnFirstSeen(t *testing.T) {
+	fake := &fakeListMetricsClient{metrics: []string{"kubernetes.pod.cpu", "kubernetes.pod.mem"}}
+	d := NewMetricDiscoverer(fake, DiscoverySinkFunc(func([]DiscoveryDelta) {}), DefaultDiscovererConfig())
+
+	deltas := collectDeltas(d, "kubernetes")
+	if len(deltas) != 2 {
+		t.Fatalf("expected 2 Added deltas, got %d: %+v", len(deltas), deltas)
+	}
+	for _, delta := range deltas {
+		if delta.Type != Added {
+			t.Fatalf("expected Added, got %v", delta.Type)
+		}
+	}
+}
+
+func TestMetricDiscovererRelistDiffsAgainstStore(t *testing.T) {
+	fake := &fakeListMetricsClient{metrics: []string{"a", "b"}}
+	d := NewMetricDiscoverer(fake, DiscoverySinkFunc(func([]DiscoveryDelta) {}), DefaultDiscovererConfig())
+	d.Relist("p")
+
+	fake.metrics = []string{"b", "c"}
+	deltas := collectDeltas(d, "p")
+
+	var added, deleted []string
+	for _, delta := range deltas {
+		switch delta.Type {
+		case Added:
+			added = append(added, delta.Metric)
+		case Deleted:
+			deleted = append(deleted, delta.Metric)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(deleted)
+
+	if len(added) != 1 || added[0] != "c" {
+		t.Fatalf("expected added=[c], got %v", added)
+	}
+	if len(deleted) != 1 || deleted[0] != "a" {
+		t.Fatalf("expected deleted=[a], got %v", deleted)
+	}
+}
+
+func TestMetricDiscovererRelistNoChangeReportsNoDeltas(t *testing.T) {
+	fake := &fakeListMetricsClient{metrics: []string{"a", "b"}}
+	var calls int
+	d := NewMetricDiscoverer(fake, DiscoverySinkFunc(func(deltas []DiscoveryDelta) {
+		calls++
+	}), DefaultDiscovererConfig())
+
+	d.Relist("p")
+	d.Relist("p")
+
+	if calls != 1 {
+		t.Fatalf("expected sink to be called once (only the initial Added batch), got %d", calls)
+	}
+}
+
+func TestMetricDiscovererMarkAndUnmarkHot(t *testing.T) {
+	fake := &fakeListMetricsClient{metrics: []string{"a"}}
+	d := NewMetricDiscoverer(fake, DiscoverySinkFunc(func([]DiscoveryDelta) {}), DefaultDiscovererConfig())
+
+	d.MarkHot("p")
+	if got := d.hotPrefixes(); len(got) != 1 || got[0] != "p" {
+		t.Fatalf("expected [p] to be hot, got %v", got)
+	}
+
+	d.UnmarkHot("p")
+	if got := d.hotPrefixes(); len(got) != 0 {
+		t.Fatalf("expected no hot prefixes after unmark, got %v", got)
+	}
+}
@@ -0,0 +1,126 @@
+package provider
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	autoscalingv2beta2 "k8s.io/api/autoscaling/v2beta2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// HPAHotSetConfig holds the tunables for an HPAHotSetRunner's poll loop.
+type HPAHotSetConfig struct {
+	// PollInterval is how often HorizontalPodAutoscalers are listed to
+	// re-evaluate which prefixes should be hot.
+	PollInterval time.Duration
+}
+
+// DefaultHPAHotSetConfig returns the HPAHotSetConfig used when the adapter
+// is not configured otherwise.
+func DefaultHPAHotSetConfig() HPAHotSetConfig {
+	return HPAHotSetConfig{PollInterval: 30 * time.Second}
+}
+
+// HPAHotSetRunner keeps a MetricDiscoverer's hot/cold state for prefix in
+// sync with whether any HorizontalPodAutoscaler in the cluster currently
+// references a Pods, Object or External metric under that prefix. This is
+// the "referenced by an active HPA" half of MetricDiscoverer's hot/cold
+// design: without it, a prefix can only ever be marked hot once at startup
+// and never demoted, regardless of whether an HPA still needs it.
+type HPAHotSetRunner struct {
+	kubeClient kubernetes.Interface
+	discoverer *MetricDiscoverer
+	prefix     string
+	cfg        HPAHotSetConfig
+}
+
+// NewHPAHotSetRunner returns an HPAHotSetRunner that promotes or demotes
+// prefix on discoverer based on HorizontalPodAutoscalers observed through
+// kubeClient.
+func NewHPAHotSetRunner(kubeClient kubernetes.Interface, discoverer *MetricDiscoverer, prefix string, cfg HPAHotSetConfig) *HPAHotSetRunner {
+	return &HPAHotSetRunner{
+		kubeClient: kubeClient,
+		discoverer: discoverer,
+		prefix:     prefix,
+		cfg:        cfg,
+	}
+}
+
+// Run evaluates prefix's hot/cold state immediately and then every
+// PollInterval, until stopCh is closed. Callers should run it in its own
+// goroutine.
+func (r *HPAHotSetRunner) Run(stopCh <-chan struct{}) {
+	r.tick()
+
+	ticker := time.NewTicker(r.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stopCh:
+			return
+		case <-ticker.C:
+			r.tick()
+		}
+	}
+}
+
+func (r *HPAHotSetRunner) tick() {
+	active, err := r.prefixHasActiveHPA()
+	if err != nil {
+		log.Errorf("HPAHotSetRunner: failed to list HorizontalPodAutoscalers: %v", err)
+		return
+	}
+
+	if active {
+		r.discoverer.MarkHot(r.prefix)
+	} else {
+		r.discoverer.UnmarkHot(r.prefix)
+	}
+}
+
+// prefixHasActiveHPA reports whether any HorizontalPodAutoscaler in the
+// cluster currently references a metric under r.prefix.
+func (r *HPAHotSetRunner) prefixHasActiveHPA() (bool, error) {
+	hpas, err := r.kubeClient.AutoscalingV2beta2().HorizontalPodAutoscalers(metav1.NamespaceAll).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return false, err
+	}
+
+	for _, hpa := range hpas.Items {
+		for _, m := range hpa.Spec.Metrics {
+			if metricSpecReferencesPrefix(m, r.prefix) {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// metricSpecReferencesPrefix reports whether m names a Pods, Object or
+// External metric whose name falls under the dot-delimited prefix (e.g.
+// prefix "kubernetes" matches "kubernetes.pod.cpu.usage_rate").
+func metricSpecReferencesPrefix(m autoscalingv2beta2.MetricSpec, prefix string) bool {
+	var name string
+	switch m.Type {
+	case autoscalingv2beta2.PodsMetricSourceType:
+		if m.Pods != nil {
+			name = m.Pods.Metric.Name
+		}
+	case autoscalingv2beta2.ObjectMetricSourceType:
+		if m.Object != nil {
+			name = m.Object.Metric.Name
+		}
+	case autoscalingv2beta2.ExternalMetricSourceType:
+		if m.External != nil {
+			name = m.External.Metric.Name
+		}
+	default:
+		return false
+	}
+	return name == prefix || strings.HasPrefix(name, prefix+".")
+}
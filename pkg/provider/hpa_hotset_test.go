@@ -0,0 +1,63 @@
+package provider
+
+import (
+	"testing"
+
+	autoscalingv2beta2 "k8s.io/api/autoscaling/v2beta2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func externalMetricHPA(namespace, name, metricName string) *autoscalingv2beta2.HorizontalPodAutoscaler {
+	return &autoscalingv2beta2.HorizontalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+		Spec: autoscalingv2beta2.HorizontalPodAutoscalerSpec{
+			Metrics: []autoscalingv2beta2.MetricSpec{
+				{
+					Type: autoscalingv2beta2.ExternalMetricSourceType,
+					External: &autoscalingv2beta2.ExternalMetricSource{
+						Metric: autoscalingv2beta2.MetricIdentifier{Name: metricName},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestHPAHotSetRunnerMarksPrefixHotWhenReferenced(t *testing.T) {
+	kubeClient := fake.NewSimpleClientset(externalMetricHPA("ns", "hpa1", "kubernetes.pod.cpu.usage_rate"))
+	d := NewMetricDiscoverer(&fakeListMetricsClient{}, DiscoverySinkFunc(func([]DiscoveryDelta) {}), DefaultDiscovererConfig())
+
+	r := NewHPAHotSetRunner(kubeClient, d, "kubernetes", DefaultHPAHotSetConfig())
+	r.tick()
+
+	got := d.hotPrefixes()
+	if len(got) != 1 || got[0] != "kubernetes" {
+		t.Fatalf("expected [kubernetes] to be hot, got %v", got)
+	}
+}
+
+func TestHPAHotSetRunnerUnmarksPrefixWhenNoLongerReferenced(t *testing.T) {
+	kubeClient := fake.NewSimpleClientset()
+	d := NewMetricDiscoverer(&fakeListMetricsClient{}, DiscoverySinkFunc(func([]DiscoveryDelta) {}), DefaultDiscovererConfig())
+	d.MarkHot("kubernetes")
+
+	r := NewHPAHotSetRunner(kubeClient, d, "kubernetes", DefaultHPAHotSetConfig())
+	r.tick()
+
+	if got := d.hotPrefixes(); len(got) != 0 {
+		t.Fatalf("expected no hot prefixes, got %v", got)
+	}
+}
+
+func TestHPAHotSetRunnerIgnoresHPAForUnrelatedMetric(t *testing.T) {
+	kubeClient := fake.NewSimpleClientset(externalMetricHPA("ns", "hpa1", "other.metric.name"))
+	d := NewMetricDiscoverer(&fakeListMetricsClient{}, DiscoverySinkFunc(func([]DiscoveryDelta) {}), DefaultDiscovererConfig())
+
+	r := NewHPAHotSetRunner(kubeClient, d, "kubernetes", DefaultHPAHotSetConfig())
+	r.tick()
+
+	if got := d.hotPrefixes(); len(got) != 0 {
+		t.Fatalf("expected no hot prefixes, got %v", got)
+	}
+}
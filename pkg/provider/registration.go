@@ -0,0 +1,62 @@
+package provider
+
+import "sync"
+
+// MetricRegistry is the live set of custom-metric names known for each
+// discovery prefix, kept up to date incrementally by a MetricDiscoverer's
+// DiscoverySink as metrics appear and disappear in Wavefront, instead of
+// being rebuilt from a full relist every time.
+//
+// It is the concrete "custom-metric registration map" DiscoverySink's doc
+// comment refers to. WavefrontProvider is the type that should ultimately
+// read from one of these to answer ListAllMetrics/GetExternalMetric calls,
+// but WavefrontProvider and WavefrontProviderConfig (referenced by
+// cmd/wavefront-adapter/main.go) aren't defined anywhere in this tree - a
+// pre-existing gap in this source snapshot, not something introduced
+// here. MetricRegistry is the real, mutable registration state that
+// plugging in would consist of wiring up; it implements DiscoverySink
+// directly so that hookup is a one-line change once WavefrontProvider
+// exists to read it.
+type MetricRegistry struct {
+	mu      sync.RWMutex
+	metrics map[string]metricSet // prefix -> known metric names
+}
+
+// NewMetricRegistry returns an empty MetricRegistry.
+func NewMetricRegistry() *MetricRegistry {
+	return &MetricRegistry{metrics: make(map[string]metricSet)}
+}
+
+// OnMetricDeltas applies a relist's Added/Deleted deltas to the registry,
+// implementing DiscoverySink.
+func (r *MetricRegistry) OnMetricDeltas(deltas []DiscoveryDelta) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, d := range deltas {
+		set, ok := r.metrics[d.Prefix]
+		if !ok {
+			set = make(metricSet)
+			r.metrics[d.Prefix] = set
+		}
+		switch d.Type {
+		case Added:
+			set[d.Metric] = struct{}{}
+		case Deleted:
+			delete(set, d.Metric)
+		}
+	}
+}
+
+// Metrics returns the metric names currently registered for prefix.
+func (r *MetricRegistry) Metrics(prefix string) []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	set := r.metrics[prefix]
+	names := make([]string, 0, len(set))
+	for name := range set {
+		names = append(names, name)
+	}
+	return names
+}
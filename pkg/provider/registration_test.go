@@ -0,0 +1,46 @@
+package provider
+
+import "testing"
+
+func hasMetric(names []string, want string) bool {
+	for _, n := range names {
+		if n == want {
+			return true
+		}
+	}
+	return false
+}
+
+func TestMetricRegistryAppliesAddedDeltas(t *testing.T) {
+	r := NewMetricRegistry()
+	r.OnMetricDeltas([]DiscoveryDelta{
+		{Type: Added, Prefix: "kubernetes", Metric: "kubernetes.pod.cpu"},
+		{Type: Added, Prefix: "kubernetes", Metric: "kubernetes.pod.mem"},
+	})
+
+	got := r.Metrics("kubernetes")
+	if len(got) != 2 || !hasMetric(got, "kubernetes.pod.cpu") || !hasMetric(got, "kubernetes.pod.mem") {
+		t.Fatalf("unexpected metrics: %v", got)
+	}
+}
+
+func TestMetricRegistryAppliesDeletedDeltas(t *testing.T) {
+	r := NewMetricRegistry()
+	r.OnMetricDeltas([]DiscoveryDelta{{Type: Added, Prefix: "p", Metric: "a"}})
+	r.OnMetricDeltas([]DiscoveryDelta{{Type: Deleted, Prefix: "p", Metric: "a"}})
+
+	if got := r.Metrics("p"); len(got) != 0 {
+		t.Fatalf("expected no metrics after delete, got %v", got)
+	}
+}
+
+func TestMetricRegistryUnknownPrefixReturnsEmpty(t *testing.T) {
+	r := NewMetricRegistry()
+	if got := r.Metrics("unknown"); len(got) != 0 {
+		t.Fatalf("expected empty, got %v", got)
+	}
+}
+
+func TestMetricRegistryImplementsDiscoverySink(t *testing.T) {
+	var _ DiscoverySink = NewMetricRegistry()
+}